@@ -0,0 +1,241 @@
+// Copyright 2026 The Fuchsia Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style license that can be
+// found in the LICENSE file.
+
+//go:build fuchsia && !build_with_native_toolchain
+// +build fuchsia,!build_with_native_toolchain
+
+// Package tuffs bridges a TUF-verified targets tree into a mountable
+// fuchsia.io directory, so that components can open verified package
+// blobs without every client wiring up TUF verification itself.
+package tuffs
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"syscall/zx"
+
+	"fidl/fuchsia/io"
+
+	"github.com/theupdateframework/go-tuf/data"
+	"github.com/theupdateframework/go-tuf/verify"
+	"thinfs/fs"
+)
+
+// ErrTargetHashMismatch is returned when a blob served for a target path
+// does not match the length/hash recorded for it in verified TUF
+// metadata.
+var ErrTargetHashMismatch = errors.New("tuffs: blob does not match verified target metadata")
+
+// MetadataSource fetches the raw bytes of a role's TUF metadata
+// manifest, e.g. "targets.json" or a delegated role's manifest.
+type MetadataSource interface {
+	FetchManifest(role string) ([]byte, error)
+}
+
+// BlobSource resolves an already-verified target path to the blob that
+// backs it.
+type BlobSource interface {
+	// OpenLocal returns a VMO opened with io.VmoFlagsRead backing
+	// targetPath's blob in-process, or ok == false if the blob isn't
+	// available locally and should be served via OpenRemote instead.
+	OpenLocal(targetPath string) (vmo *zx.VMO, size uint64, ok bool, err error)
+	// OpenRemote returns the blob server channel to hand an open
+	// request off to, for the blob named by merkleRoot (the hex-encoded
+	// sha256 recorded for the target in verified metadata). Addressing
+	// by merkleRoot rather than the original target path lets a
+	// content-addressed blob server (e.g. blobfs) enforce the same
+	// integrity check Open performs for local blobs.
+	OpenRemote(merkleRoot string) (zx.Channel, error)
+}
+
+// Directory exposes a TUF-verified targets tree as a fuchsia.io
+// directory. Open resolves the requested path against the delegation
+// graph rooted at the top-level targets role, lazily fetching and
+// verifying each delegated role's metadata through DB.Unmarshal as it is
+// reached, and hands the target's blob back either as an in-process File
+// backed by a VMO, after checking it against the length/hash recorded for
+// it in that verified metadata, or as an fs.Remote handoff to the blob
+// server, requested by that recorded sha256 and not checked by Open.
+type Directory struct {
+	db       *verify.DB
+	metadata MetadataSource
+	blobs    BlobSource
+
+	// mu guards fetched, targets and resolved, and is held for the
+	// entirety of a WalkDelegations call in resolveTarget: db itself
+	// does no locking, and WalkDelegations's visit callback calls back
+	// into verifyRoleLocked, which mutates db's roles/delegations via
+	// AddDelegatedRole/Unmarshal. Taking mu only around the cache check
+	// would let two concurrent Opens for different unresolved paths
+	// race a map read in WalkDelegations against a map write in
+	// verifyRoleLocked.
+	mu sync.Mutex
+	// fetched tracks roles already fetched and verified.
+	fetched map[string]bool
+	// targets maps a role to the TargetFileMeta it defines, keyed by
+	// target path.
+	targets map[string]map[string]data.TargetFileMeta
+	// resolved caches the TargetFileMeta a target path resolved to, so
+	// repeat opens don't re-walk the delegation graph.
+	resolved map[string]*data.TargetFileMeta
+}
+
+// NewDirectory returns a Directory that resolves opens against db,
+// fetching and verifying role metadata on demand via metadata and
+// resolving verified targets to blobs via blobs.
+func NewDirectory(db *verify.DB, metadata MetadataSource, blobs BlobSource) *Directory {
+	return &Directory{
+		db:       db,
+		metadata: metadata,
+		blobs:    blobs,
+		fetched:  make(map[string]bool),
+		targets:  make(map[string]map[string]data.TargetFileMeta),
+		resolved: make(map[string]*data.TargetFileMeta),
+	}
+}
+
+// vmoFile is an fs.FileWithBackingMemory backed by a single read-only
+// VMO, used when a verified target's blob is already resident in this
+// process.
+type vmoFile struct {
+	vmo  *zx.VMO
+	size uint64
+}
+
+func (f *vmoFile) GetBackingMemory(flags io.VmoFlags) (*zx.VMO, uint64, error) {
+	return f.vmo, f.size, nil
+}
+
+// Open resolves targetPath against the verified delegation tree and
+// returns either an in-process File backed by the target blob's VMO,
+// after checking that blob against the length/hash recorded in verified
+// metadata, or an fs.Remote handoff to the blob server for the blob named
+// by the recorded sha256; Open does not read or check a remote blob. It
+// returns
+// zx.ErrIoDataIntegrity if targetPath's metadata or any delegation
+// covering it fails to fetch or fails signature/threshold/content
+// verification, or if targetPath isn't listed in any verified targets
+// manifest at all.
+func (d *Directory) Open(targetPath string) (fs.FileWithBackingMemory, *fs.Remote, error) {
+	meta, err := d.resolveTarget(targetPath)
+	if err != nil || meta == nil {
+		return nil, nil, zx.ErrIoDataIntegrity
+	}
+
+	if vmo, size, ok, err := d.blobs.OpenLocal(targetPath); ok {
+		if err != nil {
+			return nil, nil, zx.ErrIoDataIntegrity
+		}
+		if err := verifyBlob(vmo, size, meta); err != nil {
+			return nil, nil, zx.ErrIoDataIntegrity
+		}
+		return &vmoFile{vmo: vmo, size: size}, nil, nil
+	}
+
+	ch, err := d.blobs.OpenRemote(merkleRoot(meta))
+	if err != nil {
+		return nil, nil, zx.ErrIoDataIntegrity
+	}
+	return nil, &fs.Remote{Channel: ch, Path: targetPath}, nil
+}
+
+// merkleRoot returns the hex-encoded sha256 recorded for meta, used to
+// address a target's blob by content rather than by its (attacker
+// influenced) path string.
+func merkleRoot(meta *data.TargetFileMeta) string {
+	return hex.EncodeToString(meta.Hashes["sha256"])
+}
+
+// verifyBlob checks a locally opened blob's length and sha256 against
+// meta, the TargetFileMeta recorded for it in verified TUF metadata.
+func verifyBlob(vmo *zx.VMO, size uint64, meta *data.TargetFileMeta) error {
+	if int64(size) != meta.Length {
+		return ErrTargetHashMismatch
+	}
+
+	buf := make([]byte, size)
+	if err := vmo.Read(buf, 0); err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256(buf)
+	want, ok := meta.Hashes["sha256"]
+	if !ok || !bytes.Equal(sum[:], want) {
+		return ErrTargetHashMismatch
+	}
+	return nil
+}
+
+// resolveTarget returns the TargetFileMeta that a verified role's
+// targets manifest records for targetPath, fetching and verifying role
+// metadata along the way as needed, and caching the result so repeat
+// opens don't re-walk the delegation graph. It returns a nil meta,nil
+// error if no role in the delegation graph defines targetPath.
+func (d *Directory) resolveTarget(targetPath string) (*data.TargetFileMeta, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if meta, ok := d.resolved[targetPath]; ok {
+		return meta, nil
+	}
+
+	var meta *data.TargetFileMeta
+	err := d.db.WalkDelegations(targetPath, func(role string) (bool, error) {
+		if err := d.verifyRoleLocked(role); err != nil {
+			return false, err
+		}
+		if m, ok := d.targets[role][targetPath]; ok {
+			found := m
+			meta = &found
+			return true, nil
+		}
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	d.resolved[targetPath] = meta
+	return meta, nil
+}
+
+// verifyRoleLocked fetches and verifies role's metadata through
+// DB.Unmarshal the first time it's needed, recording the target file
+// metadata it defines and registering any delegations it names so later
+// calls to WalkDelegations can discover them. Callers must hold d.mu.
+func (d *Directory) verifyRoleLocked(role string) error {
+	if d.fetched[role] {
+		return nil
+	}
+
+	b, err := d.metadata.FetchManifest(role)
+	if err != nil {
+		return err
+	}
+
+	targets := &data.Targets{}
+	if err := d.db.Unmarshal(b, targets, role, 0); err != nil {
+		return err
+	}
+	d.targets[role] = targets.Targets
+
+	if targets.Delegations != nil {
+		for _, r := range targets.Delegations.Roles {
+			if err := d.db.AddDelegatedRole(role, r.Name, r); err != nil {
+				return err
+			}
+		}
+		for id, k := range targets.Delegations.Keys {
+			if err := d.db.AddKey(id, k); err != nil {
+				return err
+			}
+		}
+	}
+
+	d.fetched[role] = true
+	return nil
+}
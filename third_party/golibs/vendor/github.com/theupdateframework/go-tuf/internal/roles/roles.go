@@ -0,0 +1,61 @@
+// Package roles classifies TUF role names and the consistent-snapshot
+// manifest filenames that carry them, so that verify and its callers
+// don't each re-implement the same string parsing.
+package roles
+
+import (
+	"strconv"
+	"strings"
+)
+
+// TopLevelRoles are the four roles every TUF repository has, as opposed
+// to the arbitrarily-named delegated targets roles a targets role may
+// introduce.
+var TopLevelRoles = map[string]struct{}{
+	"root":      {},
+	"targets":   {},
+	"snapshot":  {},
+	"timestamp": {},
+}
+
+// IsTopLevelRole reports whether name is one of the four top-level roles.
+func IsTopLevelRole(name string) bool {
+	_, ok := TopLevelRoles[name]
+	return ok
+}
+
+// IsDelegatedTargetsRole reports whether name is a delegated targets
+// role, i.e. anything other than one of the top-level roles.
+func IsDelegatedTargetsRole(name string) bool {
+	return !IsTopLevelRole(name)
+}
+
+// IsTopLevelManifest reports whether name is the unversioned manifest
+// filename of a top-level role, e.g. "root.json" or "targets.json".
+func IsTopLevelManifest(name string) bool {
+	role := strings.TrimSuffix(name, ".json")
+	return role != name && IsTopLevelRole(role)
+}
+
+// IsDelegatedTargetsManifest reports whether name is a versioned
+// manifest filename belonging to a delegated targets role, e.g.
+// "3.project-foo.json".
+func IsDelegatedTargetsManifest(name string) bool {
+	if !IsVersionedManifest(name) {
+		return false
+	}
+	role := strings.TrimSuffix(name[strings.Index(name, ".")+1:], ".json")
+	return IsDelegatedTargetsRole(role)
+}
+
+// IsVersionedManifest reports whether name has the consistent-snapshot
+// form "<version>.<role>.json": at least three dot-separated parts whose
+// first part parses as an integer version.
+func IsVersionedManifest(name string) bool {
+	parts := strings.Split(name, ".")
+	if len(parts) < 3 {
+		return false
+	}
+	_, err := strconv.Atoi(parts[0])
+	return err == nil
+}
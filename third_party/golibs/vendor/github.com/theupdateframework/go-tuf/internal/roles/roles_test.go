@@ -0,0 +1,67 @@
+package roles
+
+import "testing"
+
+func TestIsTopLevelManifest(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"targets.json", true},
+		{"root.json", true},
+		{"3.targets.json", false},
+		{"3.project-foo.json", false},
+		{"project-foo.json", false},
+		{"abc.root.json", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsTopLevelManifest(tt.name); got != tt.want {
+				t.Errorf("IsTopLevelManifest(%q) = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsVersionedManifest(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"targets.json", false},
+		{"3.targets.json", true},
+		{"3.project-foo.json", true},
+		{"3.project.foo.json", true},
+		{"abc.root.json", false},
+		{"3.json", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsVersionedManifest(tt.name); got != tt.want {
+				t.Errorf("IsVersionedManifest(%q) = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsDelegatedTargetsManifest(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"targets.json", false},
+		{"3.targets.json", false},
+		{"3.project-foo.json", true},
+		// A delegated role name may itself contain a ".", which must not
+		// be mistaken for the version/role/"json" separators.
+		{"3.project.foo.json", true},
+		{"abc.root.json", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsDelegatedTargetsManifest(tt.name); got != tt.want {
+				t.Errorf("IsDelegatedTargetsManifest(%q) = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
@@ -1,13 +1,27 @@
 package verify
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+
 	"github.com/theupdateframework/go-tuf/data"
+	"github.com/theupdateframework/go-tuf/internal/roles"
 	"github.com/theupdateframework/go-tuf/pkg/keys"
 )
 
 type Role struct {
 	KeyIDs    map[string]struct{}
 	Threshold int
+	// Paths is nil for top-level roles, which are authoritative over
+	// every target. AddDelegatedRole always sets it, so a delegated role
+	// registered that way is only authoritative over the paths it
+	// matches. NewDelegationsVerifier registers delegated roles without a
+	// PathMatcher and does not check their path-matching mode, so their
+	// Paths is nil and Matches reports true for every target.
+	Paths PathMatcher
 }
 
 func (r *Role) ValidKey(id string) bool {
@@ -15,9 +29,175 @@ func (r *Role) ValidKey(id string) bool {
 	return ok
 }
 
+// Matches reports whether this role's signatures are authoritative for
+// targetPath.
+func (r *Role) Matches(targetPath string) bool {
+	if r.Paths == nil {
+		return true
+	}
+	return r.Paths.Matches(targetPath)
+}
+
+// PathMatcher decides whether a delegated role is authoritative for a
+// given target path. A delegated role uses exactly one of the two modes
+// the TUF spec defines: glob-style path patterns, or hashed-bin prefixes.
+type PathMatcher interface {
+	Matches(targetPath string) bool
+}
+
+// globPaths matches targetPath against a set of shell-style glob
+// patterns, e.g. "foo/*.json".
+type globPaths []string
+
+func (p globPaths) Matches(targetPath string) bool {
+	for _, pattern := range p {
+		if ok, err := filepath.Match(pattern, targetPath); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// hashBinPrefixes matches targetPath by comparing hex prefixes against
+// the SHA-256 hash of targetPath, as used by hashed-bin delegations that
+// spread targets evenly across many roles.
+type hashBinPrefixes []string
+
+func (p hashBinPrefixes) Matches(targetPath string) bool {
+	sum := sha256.Sum256([]byte(targetPath))
+	hash := hex.EncodeToString(sum[:])
+	for _, prefix := range p {
+		if strings.HasPrefix(hash, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// newPathMatcher builds the PathMatcher for a delegated role, enforcing
+// that it uses exactly one of the two TUF delegation matching modes.
+func newPathMatcher(r *data.DelegatedRole) (PathMatcher, error) {
+	switch {
+	case len(r.Paths) > 0 && len(r.PathHashPrefixes) > 0:
+		return nil, ErrDelegatedRoleMatchMode
+	case len(r.Paths) > 0:
+		return globPaths(r.Paths), nil
+	case len(r.PathHashPrefixes) > 0:
+		return hashBinPrefixes(r.PathHashPrefixes), nil
+	default:
+		return nil, ErrDelegatedRoleMatchMode
+	}
+}
+
+// isSubsetOf reports whether every path child can match is also reachable
+// by parent, so a delegation can never claim authority over targets its
+// parent wasn't already trusted for (mirroring Notary's
+// isValidTargetsStructure invariant).
+func isSubsetOf(child, parent PathMatcher) bool {
+	switch c := child.(type) {
+	case globPaths:
+		p, ok := parent.(globPaths)
+		if !ok {
+			return false
+		}
+		for _, cp := range c {
+			if !globContainedByAny(p, cp) {
+				return false
+			}
+		}
+		return true
+	case hashBinPrefixes:
+		p, ok := parent.(hashBinPrefixes)
+		if !ok {
+			return false
+		}
+		for _, cp := range c {
+			if !hasAnyPrefix(p, cp) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// globContainedByAny reports whether every target path cp's pattern
+// could ever match is also matched by at least one pattern in parent. A
+// literal path (no glob metacharacters) is contained if some parent
+// pattern matches it directly; a wildcard pattern is contained if it
+// shares a literal prefix with a parent pattern of the form
+// "<literal>*" (e.g. parent "*" or "data/*" contains child
+// "data/*.json"), mirroring how Notary restricts nested delegations to
+// prefix-shaped narrowing rather than attempting general glob-subset
+// containment. A bare "*" parent is treated as matching every path
+// regardless of how many "/" segments follow, the conventional
+// TUF reading of an unrestricted delegation; any other "<literal>/*"
+// parent is scoped to its own directory, since filepath.Match's "*"
+// (used by Matches) never spans a further "/", so it only contains
+// children whose own wildcard portion doesn't introduce one.
+func globContainedByAny(parent []string, cp string) bool {
+	for _, pp := range parent {
+		if pp == cp {
+			return true
+		}
+		if !hasGlobMeta(cp) {
+			if ok, err := filepath.Match(pp, cp); err == nil && ok {
+				return true
+			}
+			continue
+		}
+		if prefix, ok := wildcardPrefix(pp); ok && strings.HasPrefix(cp, prefix) {
+			rest := cp[len(prefix):]
+			if prefix == "" || !strings.Contains(rest, "/") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func hasGlobMeta(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[")
+}
+
+// wildcardPrefix returns the literal prefix of a pattern of the form
+// "<literal>*" and whether pattern has that shape.
+func wildcardPrefix(pattern string) (string, bool) {
+	if !strings.HasSuffix(pattern, "*") {
+		return "", false
+	}
+	prefix := pattern[:len(pattern)-1]
+	return prefix, !hasGlobMeta(prefix)
+}
+
+func hasAnyPrefix(prefixes []string, s string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// delegatedEdge is one edge of the delegation graph: parent delegates to
+// child according to role (used for its Terminating flag) and matcher
+// (the path patterns / hash-bin prefixes that govern when child is
+// consulted). matcher is captured per edge, rather than read back out of
+// db.roles[child], because db.roles[child] is overwritten on every
+// AddDelegatedRole call and the same role name may legally be delegated
+// to from more than one parent with different Paths/PathHashPrefixes.
+type delegatedEdge struct {
+	child   string
+	role    *data.DelegatedRole
+	matcher PathMatcher
+}
+
 type DB struct {
-	roles     map[string]*Role
-	verifiers map[string]keys.Verifier
+	roles       map[string]*Role
+	verifiers   map[string]keys.Verifier
+	delegations map[string][]delegatedEdge
+	invalid     map[string]*data.Signed
 }
 
 func NewDB() *DB {
@@ -27,6 +207,216 @@ func NewDB() *DB {
 	}
 }
 
+// Invalid returns the most recent metadata for role that parsed
+// successfully but failed signature or threshold verification, or nil if
+// no such metadata has been recorded or a later envelope for role has
+// since passed both signature and version checks in Unmarshal. This lets an operator holding
+// fresh keys inspect what the client last rejected in order to republish
+// a correctly signed, version-bumped replacement instead of the client
+// discarding the bytes on first threshold failure.
+func (db *DB) Invalid(role string) *data.Signed {
+	return db.invalid[role]
+}
+
+// Unmarshal verifies that the signed envelope in b carries a valid
+// threshold of signatures for role and a version of at least minVersion
+// and, if so, json.Unmarshals its Signed field into v. If b parses as a
+// signed envelope but fails signature or threshold verification, the
+// envelope is retained and can be recovered later via Invalid rather than
+// being discarded; a version failure alone retains nothing.
+func (db *DB) Unmarshal(b []byte, v interface{}, role string, minVersion int) error {
+	s := &data.Signed{}
+	if err := json.Unmarshal(b, s); err != nil {
+		return err
+	}
+
+	// Only a signature/threshold failure means the bytes genuinely
+	// can't be trusted and are worth retaining for witness/re-sign
+	// recovery. A well-signed-but-stale version is an ordinary rollback
+	// rejection, not a key-rotation conflict, and must not overwrite a
+	// previously-stored genuinely-unverifiable envelope in db.invalid.
+	if err := db.VerifySignatures(s, role); err != nil {
+		if db.invalid == nil {
+			db.invalid = make(map[string]*data.Signed)
+		}
+		db.invalid[role] = s
+		return err
+	}
+
+	if err := db.checkVersion(s, minVersion); err != nil {
+		return err
+	}
+	delete(db.invalid, role)
+
+	return json.Unmarshal(s.Signed, v)
+}
+
+// Verify checks that s carries signatures from at least role's threshold
+// of distinct, known keys and that its embedded version is at least
+// minVersion.
+func (db *DB) Verify(s *data.Signed, role string, minVersion int) error {
+	if err := db.VerifySignatures(s, role); err != nil {
+		return err
+	}
+	return db.checkVersion(s, minVersion)
+}
+
+// checkVersion checks that s.Signed's embedded version is at least
+// minVersion.
+func (db *DB) checkVersion(s *data.Signed, minVersion int) error {
+	sm := &signedMeta{}
+	if err := json.Unmarshal(s.Signed, sm); err != nil {
+		return err
+	}
+	if sm.Version < minVersion {
+		return ErrLowVersion{Actual: sm.Version, Current: minVersion}
+	}
+	return nil
+}
+
+// VerifySignatures checks that s carries a valid threshold of signatures
+// from role's known keys, without checking s.Signed's version or
+// expiry.
+func (db *DB) VerifySignatures(s *data.Signed, role string) error {
+	roleData := db.GetRole(role)
+	if roleData == nil {
+		return ErrUnknownRole{Role: role}
+	}
+	if len(s.Signatures) == 0 {
+		return ErrNoSignatures
+	}
+
+	seen := make(map[string]struct{})
+	valid := 0
+	for _, sig := range s.Signatures {
+		if !roleData.ValidKey(sig.KeyID) {
+			continue
+		}
+		if _, ok := seen[sig.KeyID]; ok {
+			continue
+		}
+
+		verifier, err := db.GetVerifier(sig.KeyID)
+		if err != nil {
+			continue
+		}
+		if err := verifier.Verify(s.Signed, sig.Signature); err != nil {
+			continue
+		}
+
+		seen[sig.KeyID] = struct{}{}
+		valid++
+	}
+
+	if valid < roleData.Threshold {
+		return ErrRoleThreshold{Expected: roleData.Threshold, Actual: valid}
+	}
+	return nil
+}
+
+// signedMeta is the subset of fields common to every piece of TUF
+// metadata, used to check the version of a Signed envelope without
+// unmarshaling it into its concrete type.
+type signedMeta struct {
+	Version int `json:"version"`
+}
+
+// AddDelegatedRole records that parent delegates to child according to r,
+// registering child's keys and threshold the same way AddRole does for
+// top-level roles. parent must already be registered, via AddRole or a
+// prior AddDelegatedRole call, or this returns ErrUnknownRole. It rejects
+// a delegation whose paths are not a subset of its parent's paths with
+// ErrDelegationPathEscapes. This lets WalkDelegations and
+// GetDelegatedRolesForTarget discover child when verifying targets that
+// fall under parent.
+func (db *DB) AddDelegatedRole(parent, child string, r *data.DelegatedRole) error {
+	matcher, err := newPathMatcher(r)
+	if err != nil {
+		return err
+	}
+
+	parentRole := db.roles[parent]
+	if parentRole == nil {
+		return ErrUnknownRole{Role: parent}
+	}
+	if parentRole.Paths != nil {
+		if !isSubsetOf(matcher, parentRole.Paths) {
+			return ErrDelegationPathEscapes
+		}
+	}
+
+	role := &data.Role{Threshold: r.Threshold, KeyIDs: r.KeyIDs}
+	if err := db.addRoleWithPaths(child, role, matcher); err != nil {
+		return err
+	}
+
+	if db.delegations == nil {
+		db.delegations = make(map[string][]delegatedEdge)
+	}
+	db.delegations[parent] = append(db.delegations[parent], delegatedEdge{child: child, role: r, matcher: matcher})
+	return nil
+}
+
+// WalkDelegations performs a depth-first traversal of the delegation
+// graph rooted at the top-level targets role, descending only into
+// delegated roles whose Paths/PathHashPrefixes match targetPath. visit is
+// called for every role encountered, in the order roles must be
+// evaluated in; traversal stops early once visit returns stop == true or
+// a non-nil error. A terminating delegation (TUF spec section 5.7.3)
+// that matches targetPath ends the whole traversal once its own subtree
+// has been walked: no remaining delegation of its parent or of any
+// ancestor is evaluated, regardless of what visit returned for it or its
+// descendants. WalkDelegations returns ErrDelegationCycle if a role
+// delegates back to one of its own ancestors.
+func (db *DB) WalkDelegations(targetPath string, visit func(role string) (stop bool, err error)) error {
+	seen := make(map[string]bool)
+
+	var walk func(role string) (bool, error)
+	walk = func(role string) (bool, error) {
+		if seen[role] {
+			return false, ErrDelegationCycle
+		}
+		seen[role] = true
+		defer delete(seen, role)
+
+		stop, err := visit(role)
+		if err != nil || stop {
+			return stop, err
+		}
+
+		for _, edge := range db.delegations[role] {
+			if !edge.matcher.Matches(targetPath) {
+				continue
+			}
+
+			stop, err := walk(edge.child)
+			if err != nil {
+				return false, err
+			}
+			if stop || edge.role.Terminating {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	_, err := walk("targets")
+	return err
+}
+
+// GetDelegatedRolesForTarget returns, in the order their signatures must
+// be verified, the names of the roles responsible for targetPath,
+// starting with the top-level targets role and followed by every
+// delegated role WalkDelegations reaches for it.
+func (db *DB) GetDelegatedRolesForTarget(targetPath string) ([]string, error) {
+	var result []string
+	err := db.WalkDelegations(targetPath, func(role string) (bool, error) {
+		result = append(result, role)
+		return false, nil
+	})
+	return result, err
+}
+
 type DelegationsVerifier struct {
 	DB *DB
 }
@@ -44,7 +434,7 @@ func NewDelegationsVerifier(d *data.Delegations) (DelegationsVerifier, error) {
 		verifiers: make(map[string]keys.Verifier, len(d.Keys)),
 	}
 	for _, r := range d.Roles {
-		if _, ok := topLevelRoles[r.Name]; ok {
+		if roles.IsTopLevelRole(r.Name) {
 			return DelegationsVerifier{}, ErrInvalidDelegatedRole
 		}
 		role := &data.Role{Threshold: r.Threshold, KeyIDs: r.KeyIDs}
@@ -72,31 +462,26 @@ func (db *DB) AddKey(id string, k *data.PublicKey) error {
 	return nil
 }
 
-var topLevelRoles = map[string]struct{}{
-	"root":      {},
-	"targets":   {},
-	"snapshot":  {},
-	"timestamp": {},
-}
-
 // ValidRole checks if a role is a top level role.
 func ValidRole(name string) bool {
-	return isTopLevelRole(name)
-}
-
-func isTopLevelRole(name string) bool {
-	_, ok := topLevelRoles[name]
-	return ok
+	return roles.IsTopLevelRole(name)
 }
 
 func (db *DB) AddRole(name string, r *data.Role) error {
-	if !isTopLevelRole(name) {
+	if !roles.IsTopLevelRole(name) {
 		return ErrInvalidRole
 	}
 	return db.addRole(name, r)
 }
 
 func (db *DB) addRole(name string, r *data.Role) error {
+	return db.addRoleWithPaths(name, r, nil)
+}
+
+// addRoleWithPaths is addRole plus an optional PathMatcher restricting
+// which targets the role is authoritative for; top-level roles pass a
+// nil matcher since they are authoritative over everything.
+func (db *DB) addRoleWithPaths(name string, r *data.Role, matcher PathMatcher) error {
 	if r.Threshold < 1 {
 		return ErrInvalidThreshold
 	}
@@ -104,6 +489,7 @@ func (db *DB) addRole(name string, r *data.Role) error {
 	role := &Role{
 		KeyIDs:    make(map[string]struct{}),
 		Threshold: r.Threshold,
+		Paths:     matcher,
 	}
 	for _, id := range r.KeyIDs {
 		if len(id) != data.KeyIDLength {
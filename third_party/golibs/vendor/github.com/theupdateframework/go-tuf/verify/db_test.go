@@ -0,0 +1,234 @@
+package verify
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/theupdateframework/go-tuf/data"
+)
+
+func TestGlobContainedByAny(t *testing.T) {
+	tests := []struct {
+		name   string
+		parent []string
+		child  string
+		want   bool
+	}{
+		{"exact match", []string{"data/*.json"}, "data/*.json", true},
+		{"wildcard parent covers narrower child", []string{"*"}, "data/*.json", true},
+		{"prefix wildcard parent covers narrower child", []string{"data/*"}, "data/*.json", true},
+		{"literal child matched by parent pattern", []string{"data/*"}, "data/foo.json", true},
+		{"disjoint patterns", []string{"images/*"}, "data/*.json", false},
+		{"child widens scope", []string{"data/*.json"}, "*", false},
+		{"wildcard parent can't cross path separator", []string{"data/*"}, "data/sub/*.json", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := globContainedByAny(tt.parent, tt.child); got != tt.want {
+				t.Errorf("globContainedByAny(%v, %q) = %v, want %v", tt.parent, tt.child, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddDelegatedRoleRejectsEscapingPaths(t *testing.T) {
+	db := NewDB()
+	if err := db.AddRole("targets", &data.Role{Threshold: 1, KeyIDs: []string{}}); err != nil {
+		t.Fatalf("AddRole(targets): %v", err)
+	}
+	if err := db.AddDelegatedRole("targets", "project-foo", &data.DelegatedRole{
+		Role:  data.Role{Threshold: 1, KeyIDs: []string{}},
+		Name:  "project-foo",
+		Paths: []string{"foo/*"},
+	}); err != nil {
+		t.Fatalf("AddDelegatedRole(project-foo): %v", err)
+	}
+
+	err := db.AddDelegatedRole("project-foo", "project-foo-escape", &data.DelegatedRole{
+		Role:  data.Role{Threshold: 1, KeyIDs: []string{}},
+		Name:  "project-foo-escape",
+		Paths: []string{"bar/*"},
+	})
+	if err != ErrDelegationPathEscapes {
+		t.Errorf("AddDelegatedRole(project-foo-escape) = %v, want ErrDelegationPathEscapes", err)
+	}
+}
+
+func TestAddDelegatedRoleAllowsNarrowingPaths(t *testing.T) {
+	db := NewDB()
+	if err := db.AddRole("targets", &data.Role{Threshold: 1, KeyIDs: []string{}}); err != nil {
+		t.Fatalf("AddRole(targets): %v", err)
+	}
+	if err := db.AddDelegatedRole("targets", "project-foo", &data.DelegatedRole{
+		Role:  data.Role{Threshold: 1, KeyIDs: []string{}},
+		Name:  "project-foo",
+		Paths: []string{"*"},
+	}); err != nil {
+		t.Fatalf("AddDelegatedRole(project-foo): %v", err)
+	}
+
+	if err := db.AddDelegatedRole("project-foo", "project-foo-data", &data.DelegatedRole{
+		Role:  data.Role{Threshold: 1, KeyIDs: []string{}},
+		Name:  "project-foo-data",
+		Paths: []string{"data/*.json"},
+	}); err != nil {
+		t.Errorf("AddDelegatedRole(project-foo-data) = %v, want nil", err)
+	}
+}
+
+func TestAddDelegatedRoleRejectsUnknownParent(t *testing.T) {
+	db := NewDB()
+	err := db.AddDelegatedRole("targets", "project-foo", &data.DelegatedRole{
+		Role:  data.Role{Threshold: 1, KeyIDs: []string{}},
+		Name:  "project-foo",
+		Paths: []string{"foo/*"},
+	})
+	if _, ok := err.(ErrUnknownRole); !ok {
+		t.Errorf("AddDelegatedRole with unregistered parent = %v, want ErrUnknownRole", err)
+	}
+}
+
+// fakeVerifier always accepts, so tests can exercise signature-threshold
+// bookkeeping without real key material.
+type fakeVerifier struct{}
+
+func (fakeVerifier) Verify(msg, sig []byte) error { return nil }
+
+func signedEnvelope(t *testing.T, version int, keyID string) []byte {
+	t.Helper()
+	signedJSON, err := json.Marshal(struct {
+		Version int `json:"version"`
+	}{Version: version})
+	if err != nil {
+		t.Fatalf("marshal signed: %v", err)
+	}
+
+	var sigs []data.Signature
+	if keyID != "" {
+		sigs = []data.Signature{{KeyID: keyID, Signature: []byte("deadbeef")}}
+	}
+
+	b, err := json.Marshal(&data.Signed{Signed: signedJSON, Signatures: sigs})
+	if err != nil {
+		t.Fatalf("marshal envelope: %v", err)
+	}
+	return b
+}
+
+func TestUnmarshalRetainsInvalidOnlyForSignatureFailure(t *testing.T) {
+	const keyID = "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+
+	db := NewDB()
+	if err := db.AddRole("timestamp", &data.Role{Threshold: 1, KeyIDs: []string{keyID}}); err != nil {
+		t.Fatalf("AddRole: %v", err)
+	}
+	db.verifiers[keyID] = fakeVerifier{}
+
+	// A well-signed but stale version must not be retained as invalid:
+	// it's an ordinary rollback rejection, not evidence of the kind of
+	// key-rotation conflict an operator needs Invalid to recover from.
+	var v map[string]interface{}
+	if err := db.Unmarshal(signedEnvelope(t, 1, keyID), &v, "timestamp", 5); err == nil {
+		t.Fatal("Unmarshal(stale version) = nil error, want ErrLowVersion")
+	}
+	if got := db.Invalid("timestamp"); got != nil {
+		t.Errorf("Invalid(timestamp) after stale-but-signed version = %v, want nil", got)
+	}
+
+	// An envelope with no valid signatures genuinely can't be trusted
+	// and must be retained for witness/re-sign recovery.
+	if err := db.Unmarshal(signedEnvelope(t, 5, ""), &v, "timestamp", 0); err == nil {
+		t.Fatal("Unmarshal(no signatures) = nil error, want a signature error")
+	}
+	if got := db.Invalid("timestamp"); got == nil {
+		t.Error("Invalid(timestamp) after unsigned envelope = nil, want the retained envelope")
+	}
+}
+
+func TestWalkDelegationsDetectsCycles(t *testing.T) {
+	db := NewDB()
+	if err := db.AddRole("targets", &data.Role{Threshold: 1, KeyIDs: []string{}}); err != nil {
+		t.Fatalf("AddRole(targets): %v", err)
+	}
+	if err := db.AddDelegatedRole("targets", "a", &data.DelegatedRole{
+		Role:  data.Role{Threshold: 1, KeyIDs: []string{}},
+		Name:  "a",
+		Paths: []string{"*"},
+	}); err != nil {
+		t.Fatalf("AddDelegatedRole(a): %v", err)
+	}
+	if err := db.AddDelegatedRole("a", "b", &data.DelegatedRole{
+		Role:  data.Role{Threshold: 1, KeyIDs: []string{}},
+		Name:  "b",
+		Paths: []string{"*"},
+	}); err != nil {
+		t.Fatalf("AddDelegatedRole(b): %v", err)
+	}
+	// b delegating back to a closes a cycle targets -> a -> b -> a.
+	if err := db.AddDelegatedRole("b", "a", &data.DelegatedRole{
+		Role:  data.Role{Threshold: 1, KeyIDs: []string{}},
+		Name:  "a",
+		Paths: []string{"*"},
+	}); err != nil {
+		t.Fatalf("AddDelegatedRole(a again): %v", err)
+	}
+
+	err := db.WalkDelegations("foo.json", func(role string) (bool, error) {
+		return false, nil
+	})
+	if err != ErrDelegationCycle {
+		t.Errorf("WalkDelegations with a delegation cycle = %v, want ErrDelegationCycle", err)
+	}
+}
+
+func TestWalkDelegationsTerminatingStopsSiblings(t *testing.T) {
+	db := NewDB()
+	if err := db.AddRole("targets", &data.Role{Threshold: 1, KeyIDs: []string{}}); err != nil {
+		t.Fatalf("AddRole(targets): %v", err)
+	}
+	if err := db.AddDelegatedRole("targets", "a", &data.DelegatedRole{
+		Role:        data.Role{Threshold: 1, KeyIDs: []string{}},
+		Name:        "a",
+		Paths:       []string{"*"},
+		Terminating: true,
+	}); err != nil {
+		t.Fatalf("AddDelegatedRole(a): %v", err)
+	}
+	if err := db.AddDelegatedRole("targets", "c", &data.DelegatedRole{
+		Role:  data.Role{Threshold: 1, KeyIDs: []string{}},
+		Name:  "c",
+		Paths: []string{"*"},
+	}); err != nil {
+		t.Fatalf("AddDelegatedRole(c): %v", err)
+	}
+
+	var visited []string
+	err := db.WalkDelegations("foo.json", func(role string) (bool, error) {
+		visited = append(visited, role)
+		return false, nil
+	})
+	if err != nil {
+		t.Fatalf("WalkDelegations: %v", err)
+	}
+
+	// "a" is a terminating delegation matching foo.json, so "c" must
+	// never be visited even though "a" itself told visit to keep going.
+	want := []string{"targets", "a"}
+	if !reflect.DeepEqual(visited, want) {
+		t.Errorf("WalkDelegations visited = %v, want %v", visited, want)
+	}
+}
+
+func TestNewPathMatcherRejectsBothOrNeitherModeSet(t *testing.T) {
+	if _, err := newPathMatcher(&data.DelegatedRole{Name: "neither"}); err != ErrDelegatedRoleMatchMode {
+		t.Errorf("newPathMatcher(neither) = %v, want ErrDelegatedRoleMatchMode", err)
+	}
+	if _, err := newPathMatcher(&data.DelegatedRole{
+		Name:             "both",
+		Paths:            []string{"*"},
+		PathHashPrefixes: []string{"ab"},
+	}); err != ErrDelegatedRoleMatchMode {
+		t.Errorf("newPathMatcher(both) = %v, want ErrDelegatedRoleMatchMode", err)
+	}
+}
@@ -0,0 +1,59 @@
+package verify
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrInvalidRole            = errors.New("tuf: invalid role")
+	ErrInvalidKeyID           = errors.New("tuf: invalid key id")
+	ErrInvalidKey             = errors.New("tuf: invalid key")
+	ErrInvalidThreshold       = errors.New("tuf: invalid role threshold")
+	ErrMissingKey             = errors.New("tuf: missing key")
+	ErrInvalidDelegatedRole   = errors.New("tuf: invalid delegated role, name collides with a top-level role")
+	ErrDelegationCycle        = errors.New("tuf: delegation graph contains a cycle")
+	ErrDelegationPathEscapes  = errors.New("tuf: delegated role's paths are not a subset of its parent's paths")
+	ErrDelegatedRoleMatchMode = errors.New("tuf: delegated role must set exactly one of paths or path_hash_prefixes")
+	ErrNoSignatures           = errors.New("tuf: metadata has no signatures")
+)
+
+// ErrWrongID is returned when a key's computed ID does not match the ID
+// it was registered under.
+type ErrWrongID struct{}
+
+func (ErrWrongID) Error() string {
+	return "tuf: key id mismatch"
+}
+
+// ErrUnknownRole is returned when verification is attempted against a
+// role that hasn't been registered with the DB.
+type ErrUnknownRole struct {
+	Role string
+}
+
+func (e ErrUnknownRole) Error() string {
+	return fmt.Sprintf("tuf: unknown role %q", e.Role)
+}
+
+// ErrRoleThreshold is returned when fewer than a role's threshold of
+// valid, distinct signatures were found.
+type ErrRoleThreshold struct {
+	Expected int
+	Actual   int
+}
+
+func (e ErrRoleThreshold) Error() string {
+	return fmt.Sprintf("tuf: valid signatures did not meet threshold (%d valid, %d required)", e.Actual, e.Expected)
+}
+
+// ErrLowVersion is returned when metadata's version is lower than the
+// minimum version the caller requires.
+type ErrLowVersion struct {
+	Actual  int
+	Current int
+}
+
+func (e ErrLowVersion) Error() string {
+	return fmt.Sprintf("tuf: expected version %d or higher, got version %d", e.Current, e.Actual)
+}